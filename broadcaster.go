@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+
+	"tankgame/shared"
+)
+
+// menuRoom is the room every client not currently in a lobby is auto-joined
+// to, so lobby list changes reach them without polling.
+const menuRoom = "menu"
+
+// lobbyRoom is the per-lobby room name, joined by everyone seated in it.
+func lobbyRoom(id string) string {
+    return "lobby:" + id
+}
+
+// Broadcaster tracks named rooms of clients and fans envelopes out to
+// whoever's currently in them.
+type Broadcaster struct {
+    mu    sync.RWMutex
+    rooms map[string]map[string]*Client // room name -> client id -> client
+}
+
+func NewBroadcaster() *Broadcaster {
+    return &Broadcaster{rooms: make(map[string]map[string]*Client)}
+}
+
+// Join adds a client to a room, creating it if necessary.
+func (b *Broadcaster) Join(room string, c *Client) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    members, ok := b.rooms[room]
+    if !ok {
+        members = make(map[string]*Client)
+        b.rooms[room] = members
+    }
+    members[c.id] = c
+}
+
+// Leave removes a client from a room, cleaning the room up once it's empty.
+func (b *Broadcaster) Leave(room string, c *Client) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    members, ok := b.rooms[room]
+    if !ok {
+        return
+    }
+    delete(members, c.id)
+    if len(members) == 0 {
+        delete(b.rooms, room)
+    }
+}
+
+// Broadcast sends an envelope to every client currently in a room.
+func (b *Broadcaster) Broadcast(room string, env shared.Envelope) {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    for _, c := range b.rooms[room] {
+        c.sendEnvelope(env)
+    }
+}
+
+// JoinRoom adds a client to a named room.
+func (h *Hub) JoinRoom(c *Client, room string) {
+    h.broadcaster.Join(room, c)
+}
+
+// LeaveRoom removes a client from a named room.
+func (h *Hub) LeaveRoom(c *Client, room string) {
+    h.broadcaster.Leave(room, c)
+}
+
+// BroadcastRoom sends an envelope to every client in a named room.
+func (h *Hub) BroadcastRoom(room string, env shared.Envelope) {
+    h.broadcaster.Broadcast(room, env)
+}
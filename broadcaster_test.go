@@ -0,0 +1,93 @@
+package main
+
+import (
+    "testing"
+
+    "tankgame/shared"
+)
+
+// readSent drains the single envelope a test client's sendEnvelope call
+// queued, decoding it with the client's own JSONCodec.
+func readSent(t *testing.T, c *Client) shared.Envelope {
+    t.Helper()
+
+    select {
+    case data := <-c.send:
+        env, err := c.codec.Decode(data)
+        if err != nil {
+            t.Fatalf("decode: %v", err)
+        }
+        return env
+    default:
+        t.Fatalf("client %s: expected a queued message, got none", c.id)
+        return shared.Envelope{}
+    }
+}
+
+// TestBroadcasterReachesEveryRoomMember checks Broadcast fans an envelope
+// out to everyone currently Join'd to a room.
+func TestBroadcasterReachesEveryRoomMember(t *testing.T) {
+    b := NewBroadcaster()
+    a := newTestClient("a")
+    c := newTestClient("c")
+
+    b.Join("room", a)
+    b.Join("room", c)
+
+    b.Broadcast("room", shared.Envelope{Type: shared.MsgWorldSnapshot})
+
+    if env := readSent(t, a); env.Type != shared.MsgWorldSnapshot {
+        t.Fatalf("client a got %v, want %v", env.Type, shared.MsgWorldSnapshot)
+    }
+    if env := readSent(t, c); env.Type != shared.MsgWorldSnapshot {
+        t.Fatalf("client c got %v, want %v", env.Type, shared.MsgWorldSnapshot)
+    }
+}
+
+// TestBroadcasterLeaveStopsDelivery checks a client that Leave's a room no
+// longer receives broadcasts to it.
+func TestBroadcasterLeaveStopsDelivery(t *testing.T) {
+    b := NewBroadcaster()
+    a := newTestClient("a")
+    c := newTestClient("c")
+
+    b.Join("room", a)
+    b.Join("room", c)
+    b.Leave("room", a)
+
+    b.Broadcast("room", shared.Envelope{Type: shared.MsgWorldSnapshot})
+
+    select {
+    case <-a.send:
+        t.Fatalf("client a should not have received a broadcast after leaving the room")
+    default:
+    }
+    readSent(t, c)
+}
+
+// TestBroadcasterCleansUpEmptyRoom checks a room is removed from the
+// Broadcaster entirely once its last member leaves, rather than lingering
+// as an empty entry forever.
+func TestBroadcasterCleansUpEmptyRoom(t *testing.T) {
+    b := NewBroadcaster()
+    a := newTestClient("a")
+
+    b.Join("room", a)
+    b.Leave("room", a)
+
+    b.mu.RLock()
+    _, ok := b.rooms["room"]
+    b.mu.RUnlock()
+    if ok {
+        t.Fatalf("expected room to be removed once empty")
+    }
+}
+
+// TestBroadcasterLeaveUnknownRoomIsNoop checks Leave on a room the client
+// was never in (or that doesn't exist) doesn't panic.
+func TestBroadcasterLeaveUnknownRoomIsNoop(t *testing.T) {
+    b := NewBroadcaster()
+    a := newTestClient("a")
+
+    b.Leave("nonexistent", a)
+}
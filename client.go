@@ -0,0 +1,434 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "tankgame/shared"
+)
+
+const (
+    writeWait      = 10 * time.Second    // Max time to write a message
+    pongWait       = 60 * time.Second    // Max time to wait for pong
+    pingPeriod     = (pongWait * 9) / 10 // How often to ping
+    maxMessageSize = 4096
+)
+
+// Client represents a single connected player
+type Client struct {
+    id    string          // Unique identifier (generated on connect)
+    name  string          // Display name like "Player-abc123"
+    hub   *Hub            // Reference to the central hub (we'll build this)
+    conn  *websocket.Conn // The actual WebSocket connection
+    codec shared.Codec    // Negotiated wire format for this connection
+
+    send chan []byte     // Buffered channel for outgoing messages
+
+    // What lobby this client is in (nil if in menu)
+    lobby   *Lobby
+    lobbyMu sync.RWMutex // Protects lobby field from race conditions
+}
+
+func NewClient(id string, hub *Hub, conn *websocket.Conn, codec shared.Codec) *Client {
+    return &Client{
+        id:    id,
+        name:  "Player-" + id[:6], // Short readable name from ID
+        hub:   hub,
+        conn:  conn,
+        codec: codec,
+        send:  make(chan []byte, 64), // Buffer 64 messages
+    }
+}
+// readPump reads messages from the WebSocket and processes them
+// Runs in its own goroutine - one per client
+func (c *Client) readPump() {
+    defer func() {
+        c.hub.unregister <- c  // Tell hub we're disconnecting
+        c.conn.Close()
+    }()
+
+    c.conn.SetReadLimit(maxMessageSize)
+    c.conn.SetReadDeadline(time.Now().Add(pongWait))
+    c.conn.SetPongHandler(func(string) error {
+        c.conn.SetReadDeadline(time.Now().Add(pongWait))
+        return nil
+    })
+
+    // This loop runs forever until connection breaks
+    for {
+        _, message, err := c.conn.ReadMessage()  // BLOCKS here waiting for data
+        if err != nil {
+            if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+                log.Printf("client %s read error: %v", c.id, err)
+            }
+            break  //and cleanup from defer
+        }
+
+        c.handleMessage(message)  // Process the message TODO
+    }
+}
+
+// sends messages from the send channel to the WebSocket
+// Runs in its own goroutine - one per client
+func (c *Client) writePump() {
+    ticker := time.NewTicker(pingPeriod)
+    defer func() {
+        ticker.Stop()
+        c.conn.Close()
+    }()
+
+    for {
+        select {
+        case message, ok := <-c.send:
+            c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+            if !ok {
+                // we're being kicked
+                c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+                return
+            }
+
+            if err := c.conn.WriteMessage(c.codec.FrameType(), message); err != nil {
+                log.Printf("client %s write error: %v", c.id, err)
+                return
+            }
+
+        case <-ticker.C:
+            // periodic ping to keep connection alive
+            c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+            if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+                return
+            }
+        }
+    }
+}
+// decodePayload gets a handler its typed payload regardless of which codec
+// decoded the envelope. A BinaryCodec client already handed env.Payload to
+// us as *T straight out of gob (see wire.payloadFactories), so that case is
+// just a type assertion. A JSONCodec client's env.Payload is still a bare
+// map[string]any, so only that case pays for the marshal/unmarshal
+// round-trip needed to turn it into a concrete T.
+func decodePayload[T any](payload any) (*T, bool) {
+    if p, ok := payload.(*T); ok {
+        return p, true
+    }
+
+    var p T
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return nil, false
+    }
+    if err := json.Unmarshal(data, &p); err != nil {
+        return nil, false
+    }
+    return &p, true
+}
+
+// ------------------------------------------------------------------------
+//			HANDLERS
+// ------------------------------------------------------------------------
+
+//processes an incoming message from this client
+func (c *Client) handleMessage(data []byte) {
+    env, err := c.codec.Decode(data)
+    if err != nil {
+        log.Printf("client %s invalid message: %v", c.id, err)
+        c.sendError("invalid message format")
+        return
+    }
+
+    log.Printf("client %s sent: %s", c.id, env.Type)
+
+    // Route based on message type
+    switch env.Type {
+    case shared.MsgRequestLobbies:
+        c.hub.sendLobbyList(c)
+
+    case shared.MsgCreateLobby:
+        c.handleCreateLobby(env.Payload)
+
+    case shared.MsgJoinLobby:
+        c.handleJoinLobby(env.Payload)
+
+    case shared.MsgLeaveLobby:
+        c.handleLeaveLobby()
+
+    case shared.MsgSetReady:
+        c.handleSetReady(env.Payload)
+
+    case shared.MsgStartGame:
+        c.handleStartGame()
+
+    case shared.MsgInput:
+        c.handleInput(env.Payload)
+
+    case shared.MsgTankHit:
+        c.handleTankHit(env.Payload)
+
+    case shared.MsgInvitePlayer:
+        c.handleInvitePlayer(env.Payload)
+
+    case shared.MsgAcceptInvite:
+        c.handleAcceptInvite(env.Payload)
+
+    case shared.MsgJoinAsSpectator:
+        c.handleJoinAsSpectator(env.Payload)
+
+    default:
+        c.sendError("unknown message type: " + string(env.Type))
+    }
+}
+
+func (c *Client) handleCreateLobby(payload any) {
+    p, ok := decodePayload[shared.CreateLobbyPayload](payload)
+    if !ok || p.Name == "" {
+        c.sendError("invalid lobby name")
+        return
+    }
+
+    // Leave current lobby if in one
+    c.handleLeaveLobby()
+
+    // Create the lobby (we become host)
+    lobby := c.hub.createLobby(p.Name, c, p.Private, p.Passphrase, p.AllowSpectators)
+    c.setLobby(lobby)
+
+    // Send lobby state to us (and anyone else, but it's just us)
+    lobby.sendStateToAll()
+}
+func (c *Client) handleJoinLobby(payload any) {
+    p, ok := decodePayload[shared.JoinLobbyPayload](payload)
+    if !ok || p.LobbyID == "" {
+        c.sendError("invalid lobby id")
+        return
+    }
+
+    // Leave current lobby first
+    c.handleLeaveLobby()
+
+    // Find the lobby
+    lobby := c.hub.getLobby(p.LobbyID)
+    if lobby == nil {
+        c.sendError("lobby not found")
+        return
+    }
+
+    // Try to join (might fail if full, in-game, or private without a match)
+    if err := lobby.addPlayer(c, p.Passphrase); err != nil {
+        c.sendError(err.Error())
+        return
+    }
+
+    // Success - update our lobby reference
+    c.setLobby(lobby)
+    // Note: addPlayer already sent us the lobby state
+}
+func (c *Client) handleLeaveLobby() {
+	c.lobbyMu.Lock()
+	lobby := c.lobby
+	c.lobby = nil
+	c.lobbyMu.Unlock()
+
+	if lobby != nil {
+		lobby.leave(c)
+	}
+}
+
+func (c *Client) handleSetReady(payload any) {
+    p, ok := decodePayload[shared.SetReadyPayload](payload)
+    if !ok {
+        c.sendError("invalid ready state")
+        return
+    }
+
+    c.lobbyMu.RLock()
+    lobby := c.lobby
+    c.lobbyMu.RUnlock()
+
+    if lobby != nil {
+        lobby.setPlayerReady(c, p.Ready)
+    }
+}
+
+func (c *Client) handleStartGame() {
+    c.lobbyMu.RLock()
+    lobby := c.lobby
+    c.lobbyMu.RUnlock()
+
+    if lobby != nil {
+        lobby.tryStart(c)
+    }
+}
+
+func (c *Client) handleInput(payload any) {
+    p, ok := decodePayload[shared.InputPayload](payload)
+    if !ok {
+        c.sendError("invalid input")
+        return
+    }
+
+    lobby := c.currentLobby()
+    if lobby == nil || lobby.isSpectator(c.id) {
+        return
+    }
+    if session := lobby.activeSession(); session != nil {
+        session.HandleInput(c.id, *p)
+    }
+}
+
+func (c *Client) handleTankHit(payload any) {
+    p, ok := decodePayload[shared.TankHitPayload](payload)
+    if !ok {
+        c.sendError("invalid hit report")
+        return
+    }
+
+    lobby := c.currentLobby()
+    if lobby == nil || lobby.isSpectator(c.id) {
+        return
+    }
+    if session := lobby.activeSession(); session != nil {
+        session.HandleTankHit(c.id, *p)
+    }
+}
+
+func (c *Client) handleInvitePlayer(payload any) {
+    p, ok := decodePayload[shared.InvitePlayerPayload](payload)
+    if !ok || p.TargetID == "" {
+        c.sendError("invalid invite")
+        return
+    }
+
+    lobby := c.currentLobby()
+    if lobby == nil {
+        c.sendError("not in a lobby")
+        return
+    }
+    if !lobby.isHost(c.id) {
+        c.sendError("only the host can invite players")
+        return
+    }
+
+    target := c.hub.getClient(p.TargetID)
+    if target == nil {
+        c.sendError("player not found")
+        return
+    }
+
+    c.hub.Invite(p.TargetID, lobby.id)
+    target.sendEnvelope(shared.Envelope{
+        Type: shared.MsgInviteReceived,
+        Payload: shared.InviteReceivedPayload{
+            LobbyID:   lobby.id,
+            LobbyName: lobby.name,
+            FromName:  c.name,
+        },
+    })
+}
+
+func (c *Client) handleAcceptInvite(payload any) {
+    p, ok := decodePayload[shared.AcceptInvitePayload](payload)
+    if !ok || p.LobbyID == "" {
+        c.sendError("invalid invite accept")
+        return
+    }
+
+    lobby := c.hub.getLobby(p.LobbyID)
+    if lobby == nil {
+        c.sendError("lobby not found")
+        return
+    }
+
+    // Leave current lobby first, same as a regular join
+    c.handleLeaveLobby()
+
+    // Passphrase is left empty: addPlayer falls back to consuming the
+    // invite we're relying on here.
+    if err := lobby.addPlayer(c, ""); err != nil {
+        c.sendError(err.Error())
+        return
+    }
+
+    c.setLobby(lobby)
+}
+
+func (c *Client) handleJoinAsSpectator(payload any) {
+    p, ok := decodePayload[shared.JoinLobbyPayload](payload)
+    if !ok || p.LobbyID == "" {
+        c.sendError("invalid lobby id")
+        return
+    }
+
+    c.handleLeaveLobby()
+
+    lobby := c.hub.getLobby(p.LobbyID)
+    if lobby == nil {
+        c.sendError("lobby not found")
+        return
+    }
+
+    if err := lobby.addSpectator(c, p.Passphrase); err != nil {
+        c.sendError(err.Error())
+        return
+    }
+
+    c.setLobby(lobby)
+}
+
+func (c *Client) setLobby(l *Lobby) {
+    c.lobbyMu.Lock()
+    c.lobby = l
+    c.lobbyMu.Unlock()
+}
+
+// currentLobby returns the lobby this client is in, or nil if it's in the menu.
+func (c *Client) currentLobby() *Lobby {
+    c.lobbyMu.RLock()
+    defer c.lobbyMu.RUnlock()
+    return c.lobby
+}
+
+// reattach builds a fresh Client for a reconnecting socket that keeps this
+// client's id, name and lobby, so the rest of the system can't tell the
+// player ever dropped. The codec is re-negotiated off the new connection
+// rather than inherited, since the reconnecting client might not support
+// the same subprotocol as before.
+func (c *Client) reattach(hub *Hub, conn *websocket.Conn, codec shared.Codec) *Client {
+    nc := &Client{
+        id:    c.id,
+        name:  c.name,
+        hub:   hub,
+        conn:  conn,
+        codec: codec,
+        send:  make(chan []byte, 64),
+    }
+    nc.lobby = c.currentLobby()
+    return nc
+}
+
+// encodes and queues a message to be sent, using this connection's
+// negotiated codec
+func (c *Client) sendEnvelope(env shared.Envelope) {
+    data, err := c.codec.Encode(env)
+    if err != nil {
+        log.Printf("encode error: %v", err)
+        return
+    }
+
+    select {
+    case c.send <- data:
+        // Queued 
+    default:
+// Channel full 
+        log.Printf("client %s send buffer full, dropping", c.id)
+    }
+}
+
+func (c *Client) sendError(msg string) {
+    c.sendEnvelope(shared.Envelope{
+        Type:    shared.MsgError,
+        Payload: shared.ErrorPayload{Message: msg},
+    })
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+    "testing"
+
+    "tankgame/shared"
+)
+
+// TestDecodePayloadAcceptsTypedPointer checks the BinaryCodec path: when
+// env.Payload already arrives as *T (straight out of wire.payloadFactories),
+// decodePayload must hand it straight back rather than going anywhere near
+// encoding/json.
+func TestDecodePayloadAcceptsTypedPointer(t *testing.T) {
+    want := &shared.InputPayload{MoveX: 1, MoveY: -1, Fire: true}
+
+    got, ok := decodePayload[shared.InputPayload](want)
+    if !ok {
+        t.Fatalf("decodePayload rejected an already-typed *InputPayload")
+    }
+    if got != want {
+        t.Fatalf("decodePayload should return the same pointer for the BinaryCodec case, got a copy")
+    }
+}
+
+// TestDecodePayloadFallsBackToJSON checks the JSONCodec path: env.Payload
+// arrives as map[string]any (what json.Unmarshal leaves an any field with),
+// and decodePayload must still produce a usable typed value for it.
+func TestDecodePayloadFallsBackToJSON(t *testing.T) {
+    payload := map[string]any{"lobby_id": "abc123", "passphrase": "secret"}
+
+    got, ok := decodePayload[shared.JoinLobbyPayload](payload)
+    if !ok {
+        t.Fatalf("decodePayload rejected a map[string]any payload")
+    }
+    if got.LobbyID != "abc123" || got.Passphrase != "secret" {
+        t.Fatalf("got %+v, want LobbyID=abc123 Passphrase=secret", *got)
+    }
+}
+
+// TestDecodePayloadRejectsWrongType checks a payload that's neither the
+// expected *T nor a JSON-shaped map still fails cleanly instead of panicking.
+func TestDecodePayloadRejectsWrongType(t *testing.T) {
+    if _, ok := decodePayload[shared.JoinLobbyPayload](42); ok {
+        t.Fatalf("expected decodePayload to reject an unrelated payload type")
+    }
+}
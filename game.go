@@ -0,0 +1,203 @@
+package main
+
+import (
+    "sync"
+    "time"
+
+    "tankgame/shared"
+)
+
+const (
+    tickRate     = 30
+    tickInterval = time.Second / tickRate
+)
+
+// tankState is one tank's authoritative state in a running game.
+type tankState struct {
+    id     string
+    x, y   float64
+    vx, vy float64
+    hp     int
+    dead   bool
+}
+
+// worldState is the authoritative simulation a GameSession advances each
+// tick. Only the session's own goroutine touches it.
+type worldState struct {
+    tick  uint64
+    tanks map[string]*tankState
+}
+
+// GameSession owns a running match for a single lobby: it ticks at a fixed
+// rate on its own goroutine, applies queued input and hit reports, and
+// broadcasts world snapshots to every player.
+type GameSession struct {
+    lobby *Lobby
+    world *worldState
+
+    inputs chan inputEvent
+    hits   chan hitEvent
+    stop   chan struct{}
+    once   sync.Once
+}
+
+type inputEvent struct {
+    clientID string
+    payload  shared.InputPayload
+}
+
+type hitEvent struct {
+    payload shared.TankHitPayload
+    // forced bypasses the reported-damage clamp below; only MarkDisconnected
+    // sets it, for the one case where the server itself - not a client -
+    // is the source of the damage.
+    forced bool
+}
+
+// maxReportedDamage caps how much hp a single client-reported hit can take
+// off a tank. Clients only ever report hits they believe landed; the server
+// doesn't trust the damage figure any further than this, since nothing here
+// yet recomputes hits from tank positions to confirm it was plausible.
+const maxReportedDamage = 20
+
+// NewGameSession creates a session with one tank per player, spread out
+// along the x axis at full health.
+func NewGameSession(l *Lobby, playerIDs []string) *GameSession {
+    tanks := make(map[string]*tankState, len(playerIDs))
+    for i, id := range playerIDs {
+        tanks[id] = &tankState{id: id, x: float64(i) * 2, hp: 100}
+    }
+
+    return &GameSession{
+        lobby:  l,
+        world:  &worldState{tanks: tanks},
+        inputs: make(chan inputEvent, 64),
+        hits:   make(chan hitEvent, 64),
+        stop:   make(chan struct{}),
+    }
+}
+
+// Run drives the fixed-rate tick loop. Meant to be started in its own
+// goroutine; returns once the match ends or the session is stopped.
+func (gs *GameSession) Run() {
+    ticker := time.NewTicker(tickInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-gs.stop:
+            return
+
+        case in := <-gs.inputs:
+            gs.applyInput(in)
+
+        case hit := <-gs.hits:
+            gs.applyHit(hit)
+
+        case <-ticker.C:
+            gs.world.tick++
+            gs.broadcastSnapshot()
+            if winner, over := gs.checkGameOver(); over {
+                gs.lobby.endGame(winner)
+                return
+            }
+        }
+    }
+}
+
+// Stop ends the tick loop, e.g. because the lobby emptied out from under it.
+func (gs *GameSession) Stop() {
+    gs.once.Do(func() { close(gs.stop) })
+}
+
+// HandleInput queues a client's latest movement/fire input for the next
+// tick. Safe to call from any client's goroutine.
+func (gs *GameSession) HandleInput(clientID string, p shared.InputPayload) {
+    select {
+    case gs.inputs <- inputEvent{clientID: clientID, payload: p}:
+    default: // session is backed up; drop the stale input rather than block
+    }
+}
+
+// HandleTankHit queues a client-reported hit for the server to apply. The
+// reported damage is only ever taken as a hint, not a command - see
+// maxReportedDamage.
+func (gs *GameSession) HandleTankHit(clientID string, p shared.TankHitPayload) {
+    select {
+    case gs.hits <- hitEvent{payload: p}:
+    default:
+    }
+}
+
+// MarkDisconnected kills a tank immediately without ending the match, so
+// the remaining players keep playing. This damage comes from the server
+// itself, so it's marked forced to skip the reported-damage clamp.
+func (gs *GameSession) MarkDisconnected(clientID string) {
+    select {
+    case gs.hits <- hitEvent{payload: shared.TankHitPayload{TargetID: clientID, Damage: 1 << 30}, forced: true}:
+    default:
+    }
+}
+
+func (gs *GameSession) applyInput(in inputEvent) {
+    tank := gs.world.tanks[in.clientID]
+    if tank == nil || tank.dead {
+        return
+    }
+
+    tank.vx, tank.vy = in.payload.MoveX, in.payload.MoveY
+    tank.x += tank.vx / tickRate
+    tank.y += tank.vy / tickRate
+}
+
+func (gs *GameSession) applyHit(hit hitEvent) {
+    tank := gs.world.tanks[hit.payload.TargetID]
+    if tank == nil || tank.dead {
+        return
+    }
+
+    damage := hit.payload.Damage
+    if !hit.forced {
+        if damage <= 0 {
+            return
+        }
+        if damage > maxReportedDamage {
+            damage = maxReportedDamage
+        }
+    }
+
+    tank.hp -= damage
+    if tank.hp <= 0 {
+        tank.hp = 0
+        tank.dead = true
+    }
+}
+
+// checkGameOver reports the remaining tank's id once at most one is alive.
+func (gs *GameSession) checkGameOver() (winnerID string, over bool) {
+    alive := 0
+    for id, t := range gs.world.tanks {
+        if !t.dead {
+            alive++
+            winnerID = id
+        }
+    }
+    return winnerID, alive <= 1
+}
+
+func (gs *GameSession) broadcastSnapshot() {
+    tanks := make([]shared.TankSnapshot, 0, len(gs.world.tanks))
+    for _, t := range gs.world.tanks {
+        tanks = append(tanks, shared.TankSnapshot{
+            ID: t.id, X: t.x, Y: t.y, HP: t.hp, Dead: t.dead,
+        })
+    }
+
+    gs.lobby.broadcastAll(shared.Envelope{
+        Type: shared.MsgWorldSnapshot,
+        Payload: shared.WorldSnapshotPayload{
+            Tick:  gs.world.tick,
+            Tanks: tanks,
+        },
+    })
+}
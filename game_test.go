@@ -0,0 +1,103 @@
+package main
+
+import (
+    "testing"
+
+    "tankgame/shared"
+)
+
+// TestApplyHitClampsReportedDamage checks the security-relevant clamp: a
+// client-reported hit can never take more than maxReportedDamage off a
+// tank's hp, however much damage the client claims.
+func TestApplyHitClampsReportedDamage(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: 9999}})
+
+    tank := gs.world.tanks["a"]
+    if want := 100 - maxReportedDamage; tank.hp != want {
+        t.Fatalf("got hp %d, want %d (damage should be clamped to %d)", tank.hp, want, maxReportedDamage)
+    }
+}
+
+// TestApplyHitIgnoresNonPositiveDamage checks a client can't heal (or
+// no-op) a tank by reporting zero or negative damage.
+func TestApplyHitIgnoresNonPositiveDamage(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: -50}})
+
+    if tank := gs.world.tanks["a"]; tank.hp != 100 {
+        t.Fatalf("got hp %d, want 100 (non-positive damage should be ignored)", tank.hp)
+    }
+}
+
+// TestApplyHitForcedBypassesClamp checks MarkDisconnected's forced hit,
+// the one case of server-sourced damage, isn't subject to the
+// client-report clamp.
+func TestApplyHitForcedBypassesClamp(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: 1 << 30}, forced: true})
+
+    tank := gs.world.tanks["a"]
+    if !tank.dead || tank.hp != 0 {
+        t.Fatalf("got dead=%v hp=%d, want a forced hit to kill the tank outright", tank.dead, tank.hp)
+    }
+}
+
+// TestApplyHitIgnoresDeadTank checks a hit on an already-dead tank is a
+// no-op rather than driving hp further negative.
+func TestApplyHitIgnoresDeadTank(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: 1 << 30}, forced: true})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: 10}, forced: true})
+
+    if tank := gs.world.tanks["a"]; tank.hp != 0 {
+        t.Fatalf("got hp %d, want 0 (a dead tank should not take further damage)", tank.hp)
+    }
+}
+
+// TestCheckGameOverWaitsForOneSurvivor checks the match isn't reported over
+// while two or more tanks are still alive.
+func TestCheckGameOverWaitsForOneSurvivor(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a", "b"})
+
+    if _, over := gs.checkGameOver(); over {
+        t.Fatalf("expected game not over with two tanks alive")
+    }
+}
+
+// TestCheckGameOverReportsLastSurvivor checks the remaining tank is
+// reported as the winner once every other tank is dead.
+func TestCheckGameOverReportsLastSurvivor(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a", "b"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "b", Damage: 1 << 30}, forced: true})
+
+    winner, over := gs.checkGameOver()
+    if !over || winner != "a" {
+        t.Fatalf("got winner=%q over=%v, want winner=a over=true", winner, over)
+    }
+}
+
+// TestApplyInputMovesTank checks input advances tank position by velocity
+// scaled to one tick's worth of movement.
+func TestApplyInputMovesTank(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyInput(inputEvent{clientID: "a", payload: shared.InputPayload{MoveX: tickRate, MoveY: 0}})
+
+    tank := gs.world.tanks["a"]
+    if tank.x != 1 || tank.y != 0 {
+        t.Fatalf("got x=%v y=%v, want x=1 y=0 after one tick at MoveX=tickRate", tank.x, tank.y)
+    }
+}
+
+// TestApplyInputIgnoresDeadTank checks a dead tank can't be moved by a
+// late-arriving input.
+func TestApplyInputIgnoresDeadTank(t *testing.T) {
+    gs := NewGameSession(nil, []string{"a"})
+    gs.applyHit(hitEvent{payload: shared.TankHitPayload{TargetID: "a", Damage: 1 << 30}, forced: true})
+    gs.applyInput(inputEvent{clientID: "a", payload: shared.InputPayload{MoveX: tickRate, MoveY: tickRate}})
+
+    tank := gs.world.tanks["a"]
+    if tank.x != 0 || tank.y != 0 {
+        t.Fatalf("got x=%v y=%v, want a dead tank to stay put", tank.x, tank.y)
+    }
+}
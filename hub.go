@@ -1,10 +1,30 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
 	"sync"
+	"time"
+
 	"tankgame/shared"
 )
 
+// defaultReconnectGracePeriod is how long a disconnected client's lobby slot
+// is held open for a reconnect before it's evicted for real, used whenever a
+// deployment doesn't configure its own via NewHub.
+const defaultReconnectGracePeriod = 30 * time.Second
+
+// reconnectHandle preserves a disconnected client so it can be handed back
+// to a reconnecting socket before the grace period expires.
+type reconnectHandle struct {
+    client *Client
+    timer  *time.Timer
+}
+
 // Active clients and labels
 type Hub struct {
     // id -> Client
@@ -15,53 +35,220 @@ type Hub struct {
     lobbies   map[string]*Lobby
     lobbiesMu sync.RWMutex
 
-    // client registration 
+    // client registration
     register   chan *Client
     unregister chan *Client
+
+    // reconnect token signing and pending grace-period handles
+    reconnectSecret      []byte
+    reconnect            map[string]*reconnectHandle
+    reconnectMu          sync.Mutex
+    reconnectGracePeriod time.Duration
+
+    // room-based fan-out for menu and lobby broadcasts
+    broadcaster *Broadcaster
+
+    // pending invites into private lobbies
+    invites *Invites
 }
 
-func NewHub() *Hub {
+// NewHub builds a Hub that holds a disconnected client's lobby slot open
+// for gracePeriod before evicting it for real. Pass 0 to use
+// defaultReconnectGracePeriod.
+func NewHub(gracePeriod time.Duration) *Hub {
+    if gracePeriod <= 0 {
+        gracePeriod = defaultReconnectGracePeriod
+    }
+
+    secret := make([]byte, 32)
+    rand.Read(secret)
+
     return &Hub{
-        clients:    make(map[string]*Client),
-        lobbies:    make(map[string]*Lobby),
-        register:   make(chan *Client),
-        unregister: make(chan *Client),
+        clients:              make(map[string]*Client),
+        lobbies:              make(map[string]*Lobby),
+        register:             make(chan *Client),
+        unregister:           make(chan *Client),
+        reconnectSecret:      secret,
+        reconnect:            make(map[string]*reconnectHandle),
+        reconnectGracePeriod: gracePeriod,
+        broadcaster:          NewBroadcaster(),
+        invites:              NewInvites(),
     }
 }
-// Run starts the hub's main loop 
+// Run starts the hub's main loop
 func (h *Hub) Run() {
     for {
         select {
         case client := <-h.register:
-            h.clientsMu.Lock()
-            h.clients[client.id] = client
-            h.clientsMu.Unlock()
-            log.Printf("client registered: %s (total: %d)", client.id, len(h.clients))
+            h.registerClient(client)
 
         case client := <-h.unregister:
-            h.clientsMu.Lock()
-            if _, ok := h.clients[client.id]; ok {
-                // Clean up: leave lobby if in one
-                client.handleLeaveLobby()
-                // Close send channel (signals writePump to exit)
-                close(client.send)
-                delete(h.clients, client.id)
-                log.Printf("client unregistered: %s (total: %d)", client.id, len(h.clients))
-            }
-            h.clientsMu.Unlock()
+            h.evictOnDisconnect(client)
         }
     }
 }
+
+// registerClient adds a client to the hub and, unless it's a reconnect
+// already seated in a lobby (set by reattach before it ever reaches here),
+// joins it to the menu room so lobby list changes reach it without having
+// to poll MsgRequestLobbies.
+func (h *Hub) registerClient(client *Client) {
+    h.clientsMu.Lock()
+    h.clients[client.id] = client
+    h.clientsMu.Unlock()
+    log.Printf("client registered: %s (total: %d)", client.id, len(h.clients))
+
+    if client.currentLobby() == nil {
+        h.JoinRoom(client, menuRoom)
+        h.sendLobbyList(client)
+    }
+}
+
+// evictOnDisconnect removes a dropped client from the hub and every
+// broadcast room it was sitting in before closing its send channel, so no
+// in-flight Broadcast (e.g. a GameSession's 30Hz world snapshot) can reach a
+// closed channel and panic. The client's lobby slot itself is left in place
+// for h.reconnectGracePeriod in case it reconnects.
+func (h *Hub) evictOnDisconnect(client *Client) {
+    h.clientsMu.Lock()
+    _, ok := h.clients[client.id]
+    if ok {
+        delete(h.clients, client.id)
+    }
+    h.clientsMu.Unlock()
+    if !ok {
+        return
+    }
+
+    log.Printf("client unregistered: %s (total: %d)", client.id, len(h.clients))
+
+    h.LeaveRoom(client, menuRoom)
+    if lobby := client.currentLobby(); lobby != nil {
+        h.LeaveRoom(client, lobbyRoom(lobby.id))
+    }
+
+    // Only now is it safe to close the send channel - nothing still
+    // broadcasting to this client's rooms.
+    close(client.send)
+
+    // Hold their lobby slot open in case they reconnect instead of
+    // evicting them immediately.
+    h.beginReconnectGrace(client)
+}
+
+// beginReconnectGrace holds a disconnected client's lobby slot for
+// h.reconnectGracePeriod. If nobody reclaims it in time, the client is
+// evicted from its lobby the same way an immediate disconnect used to.
+func (h *Hub) beginReconnectGrace(c *Client) {
+    h.reconnectMu.Lock()
+    defer h.reconnectMu.Unlock()
+
+    handle := &reconnectHandle{client: c}
+    handle.timer = time.AfterFunc(h.reconnectGracePeriod, func() {
+        h.expireReconnect(c, handle)
+    })
+    h.reconnect[c.id] = handle
+}
+
+// expireReconnect evicts c from its lobby once its reconnect grace period
+// elapses. It only does so if handle is still the one on file for c.id:
+// the timer can fire at the same moment reclaimReconnect is already
+// reclaiming this same handle (Stop() losing the race), and without this
+// check the stale callback would evict the live, freshly-reattached
+// session right out from under a client that reconnected just in time.
+func (h *Hub) expireReconnect(c *Client, handle *reconnectHandle) {
+    h.reconnectMu.Lock()
+    current, ok := h.reconnect[c.id]
+    if !ok || current != handle {
+        h.reconnectMu.Unlock()
+        return
+    }
+    delete(h.reconnect, c.id)
+    h.reconnectMu.Unlock()
+
+    log.Printf("client %s reconnect grace expired, evicting", c.id)
+    c.handleLeaveLobby()
+}
+
+// reclaimReconnect cancels a pending grace-period eviction and hands back
+// the disconnected client it was holding, if any.
+func (h *Hub) reclaimReconnect(clientID string) (*Client, bool) {
+    h.reconnectMu.Lock()
+    defer h.reconnectMu.Unlock()
+
+    handle, ok := h.reconnect[clientID]
+    if !ok {
+        return nil, false
+    }
+    // Stop is best-effort: if the timer already fired, its callback is
+    // queued behind reconnectMu and will see this handle is no longer
+    // the one on file (deleted below) and no-op instead of evicting.
+    handle.timer.Stop()
+    delete(h.reconnect, clientID)
+    return handle.client, true
+}
+
+// isLive reports whether a client id currently has a live connection
+// registered with the hub.
+func (h *Hub) isLive(id string) bool {
+    h.clientsMu.RLock()
+    defer h.clientsMu.RUnlock()
+    _, ok := h.clients[id]
+    return ok
+}
+
+// getClient looks up a connected client by id, or nil if none is connected.
+func (h *Hub) getClient(id string) *Client {
+    h.clientsMu.RLock()
+    defer h.clientsMu.RUnlock()
+    return h.clients[id]
+}
+
+// newSessionToken mints a signed reconnect token for a client id. The token
+// is just the id plus an HMAC of it, so it's cheap to verify and carries no
+// state of its own.
+func (h *Hub) newSessionToken(clientID string) string {
+    mac := hmac.New(sha256.New, h.reconnectSecret)
+    mac.Write([]byte(clientID))
+    return clientID + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySessionToken checks a token minted by newSessionToken and, if
+// valid, returns the client id it was issued for.
+func (h *Hub) verifySessionToken(token string) (string, bool) {
+    i := strings.IndexByte(token, '.')
+    if i < 0 {
+        return "", false
+    }
+
+    clientID := token[:i]
+    expected := h.newSessionToken(clientID)
+    if !hmac.Equal([]byte(token), []byte(expected)) {
+        return "", false
+    }
+    return clientID, true
+}
 // new lobby with given host
-func (h *Hub) createLobby(name string, host *Client) *Lobby {
+func (h *Hub) createLobby(name string, host *Client, private bool, passphrase string, allowSpectators bool) *Lobby {
     h.lobbiesMu.Lock()
-    defer h.lobbiesMu.Unlock()
-
     id := generateID()  // We'll write this helper
-    lobby := NewLobby(id, name, host, h)
+    lobby := NewLobby(id, name, host, h, private, passphrase, allowSpectators)
     h.lobbies[id] = lobby
+    h.lobbiesMu.Unlock()
 
     log.Printf("lobby created: %s (%s)", name, id)
+
+    h.LeaveRoom(host, menuRoom)
+    h.JoinRoom(host, lobbyRoom(id))
+
+    // Private lobbies are unlisted, so the menu never hears about them.
+    if !private {
+        h.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaAdd, Lobby: lobby.Info()},
+        })
+    }
+
     return lobby
 }
 
@@ -75,18 +262,33 @@ func (h *Hub) getLobby(id string) *Lobby {
 // deletes a lobby (called when it becomes empty)
 func (h *Hub) removeLobby(id string) {
     h.lobbiesMu.Lock()
-    defer h.lobbiesMu.Unlock()
+    lobby := h.lobbies[id]
     delete(h.lobbies, id)
+    h.lobbiesMu.Unlock()
+
     log.Printf("lobby removed: %s", id)
+
+    if lobby != nil && !lobby.private {
+        h.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaRemove, Lobby: shared.LobbyInfo{ID: id}},
+        })
+    }
 }
 
-// sendLobbyList sends the current list of lobbies to a client
+// sendLobbyList sends the current full list of public lobbies to a client.
+// Sent on connect and again whenever the client asks with MsgRequestLobbies;
+// in between, a client in the menu room stays current via room deltas.
+// Private lobbies are never included.
 func (h *Hub) sendLobbyList(c *Client) {
     h.lobbiesMu.RLock()
     defer h.lobbiesMu.RUnlock()
 
     lobbies := make([]shared.LobbyInfo, 0, len(h.lobbies))
     for _, lobby := range h.lobbies {
+        if lobby.private {
+            continue
+        }
         lobbies = append(lobbies, lobby.Info())
     }
 
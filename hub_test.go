@@ -0,0 +1,331 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "tankgame/shared"
+)
+
+// newTestClient builds a bare Client suitable for exercising hub/lobby
+// bookkeeping without a real websocket connection - nothing here calls
+// readPump/writePump, just sendEnvelope and the room maps.
+func newTestClient(id string) *Client {
+    return &Client{
+        id:    id,
+        name:  "Player-" + id,
+        codec: shared.JSONCodec{},
+        send:  make(chan []byte, 64),
+    }
+}
+
+// TestEvictOnDisconnectStopsLobbyBroadcasts simulates a mid-game disconnect:
+// a client seated in a lobby room drops, and the lobby's GameSession keeps
+// ticking snapshots to that room during the reconnect grace period. Before
+// the fix, the dropped client's send channel was closed while it was still
+// a room member, so this broadcast would panic on a closed channel send.
+func TestEvictOnDisconnectStopsLobbyBroadcasts(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    guest := newTestClient("guest")
+
+    hub.clientsMu.Lock()
+    hub.clients[host.id] = host
+    hub.clients[guest.id] = guest
+    hub.clientsMu.Unlock()
+
+    lobby := hub.createLobby("test lobby", host, false, "", false)
+    if err := lobby.addPlayer(guest, ""); err != nil {
+        t.Fatalf("addPlayer: %v", err)
+    }
+    // addPlayer itself never updates Client.lobby - every real caller
+    // (handleJoinLobby) sets it right after a successful join, and
+    // evictOnDisconnect relies on that to know which lobby room to leave.
+    guest.setLobby(lobby)
+
+    hub.evictOnDisconnect(guest)
+
+    defer func() {
+        if r := recover(); r != nil {
+            t.Fatalf("broadcast after disconnect panicked: %v", r)
+        }
+    }()
+    hub.BroadcastRoom(lobbyRoom(lobby.id), shared.Envelope{Type: shared.MsgWorldSnapshot})
+}
+
+// TestEvictOnDisconnectHoldsReconnectGrace checks the disconnected client's
+// lobby slot is still reclaimable, i.e. evictOnDisconnect didn't just evict
+// outright.
+func TestEvictOnDisconnectHoldsReconnectGrace(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+
+    hub.clientsMu.Lock()
+    hub.clients[host.id] = host
+    hub.clientsMu.Unlock()
+
+    lobby := hub.createLobby("test lobby", host, false, "", false)
+    hub.evictOnDisconnect(host)
+
+    reclaimed, ok := hub.reclaimReconnect(host.id)
+    if !ok || reclaimed != host {
+        t.Fatalf("expected host to be reclaimable after disconnect")
+    }
+    if _, stillPlayer := lobby.players[host.id]; !stillPlayer {
+        t.Fatalf("expected lobby slot to survive the grace period")
+    }
+}
+
+// TestExpireReconnectIgnoresStaleHandle reproduces the race between the
+// grace-period timer and a concurrent reclaimReconnect: if the timer fires
+// at the same moment the client reconnects, its callback (expireReconnect)
+// must not evict the freshly-reattached live player just because it's
+// running late - only if it's still the handle reclaimReconnect hasn't
+// already taken.
+func TestExpireReconnectIgnoresStaleHandle(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+
+    hub.clientsMu.Lock()
+    hub.clients[host.id] = host
+    hub.clientsMu.Unlock()
+
+    lobby := hub.createLobby("test lobby", host, false, "", false)
+    hub.evictOnDisconnect(host)
+
+    reclaimed, ok := hub.reclaimReconnect(host.id)
+    if !ok || reclaimed != host {
+        t.Fatalf("expected host to be reclaimable after disconnect")
+    }
+
+    // Simulate reconnectWs: a fresh *Client takes over the lobby slot.
+    reconnected := reclaimed.reattach(hub, nil, shared.JSONCodec{})
+    lobby.reattachPlayer(reconnected)
+
+    // The stale handle's timer callback runs after reclaimReconnect already
+    // won the race and deleted it from hub.reconnect - it must no-op rather
+    // than evicting the reconnected player.
+    staleHandle := &reconnectHandle{client: reclaimed}
+    hub.expireReconnect(reclaimed, staleHandle)
+
+    if _, stillPlayer := lobby.players[host.id]; !stillPlayer {
+        t.Fatalf("stale expireReconnect call evicted the reconnected player")
+    }
+}
+
+// TestRegisterSkipsMenuRoomForReattachedClient guards against a reconnecting
+// player (already seated in a lobby via reattach) being joined to the menu
+// room too, which would leave them receiving lobby-list deltas forever.
+func TestRegisterSkipsMenuRoomForReattachedClient(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    hub.registerClient(host)
+
+    lobby := hub.createLobby("test lobby", host, false, "", false)
+
+    // Simulate reattach: a fresh *Client for the same id, already pointed
+    // at its old lobby, arriving at the hub's register case.
+    reconnected := newTestClient(host.id)
+    reconnected.lobby = lobby
+    hub.registerClient(reconnected)
+
+    hub.broadcaster.mu.RLock()
+    _, inMenu := hub.broadcaster.rooms[menuRoom][reconnected.id]
+    hub.broadcaster.mu.RUnlock()
+    if inMenu {
+        t.Fatalf("reattached client should not have been auto-joined to the menu room")
+    }
+}
+
+// TestReattachSpectatorReplacesStaleClient guards against the panic a
+// reconnecting spectator used to cause: before reattachSpectator existed,
+// reconnectWs always called reattachPlayer, which only looks at l.players
+// and silently no-ops for a spectator id, leaving the old closed-channel
+// *Client sitting in l.spectators. The very next sendStateToAll would then
+// send on that closed channel and panic.
+func TestReattachSpectatorReplacesStaleClient(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    spectator := newTestClient("spectator")
+
+    hub.clientsMu.Lock()
+    hub.clients[host.id] = host
+    hub.clients[spectator.id] = spectator
+    hub.clientsMu.Unlock()
+
+    lobby := hub.createLobby("test lobby", host, false, "", true)
+    if err := lobby.addSpectator(spectator, ""); err != nil {
+        t.Fatalf("addSpectator: %v", err)
+    }
+    spectator.setLobby(lobby)
+
+    hub.evictOnDisconnect(spectator)
+
+    reclaimed, ok := hub.reclaimReconnect(spectator.id)
+    if !ok || reclaimed != spectator {
+        t.Fatalf("expected spectator to be reclaimable after disconnect")
+    }
+
+    reconnected := newTestClient(spectator.id)
+    reconnected.lobby = lobby
+    lobby.reattachSpectator(reconnected)
+
+    if lobby.spectators[spectator.id] != reconnected {
+        t.Fatalf("reattachSpectator did not replace the stale client in l.spectators")
+    }
+
+    defer func() {
+        if r := recover(); r != nil {
+            t.Fatalf("sendStateToAll panicked against the stale spectator client: %v", r)
+        }
+    }()
+    lobby.sendStateToAll()
+}
+
+// dialWs opens a real WebSocket connection to srv's /ws endpoint, resuming
+// a previous session via token if one is given, and returns the connection
+// plus the session envelope the server sends immediately after accepting it.
+func dialWs(t *testing.T, srv *httptest.Server, token string) (*websocket.Conn, shared.SessionPayload) {
+    t.Helper()
+
+    url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+    if token != "" {
+        url += "?token=" + token
+    }
+
+    conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+    if err != nil {
+        t.Fatalf("dial: %v", err)
+    }
+
+    var session shared.SessionPayload
+    readUntil(t, conn, shared.MsgSession, &session)
+    return conn, session
+}
+
+// readEnvelope reads the next JSON envelope off conn and, if out is
+// non-nil, decodes its payload into it. It returns the envelope's type so
+// callers can assert on it.
+func readEnvelope(t *testing.T, conn *websocket.Conn, out any) shared.MessageType {
+    t.Helper()
+
+    _, data, err := conn.ReadMessage()
+    if err != nil {
+        t.Fatalf("read: %v", err)
+    }
+
+    var env struct {
+        Type    shared.MessageType `json:"type"`
+        Payload json.RawMessage    `json:"payload"`
+    }
+    if err := json.Unmarshal(data, &env); err != nil {
+        t.Fatalf("unmarshal envelope: %v", err)
+    }
+    if out != nil && len(env.Payload) > 0 {
+        if err := json.Unmarshal(env.Payload, out); err != nil {
+            t.Fatalf("unmarshal %s payload: %v", env.Type, err)
+        }
+    }
+    return env.Type
+}
+
+// sendEnvelope marshals and writes a client -> server envelope over conn.
+func sendEnvelope(t *testing.T, conn *websocket.Conn, msgType shared.MessageType, payload any) {
+    t.Helper()
+
+    data, err := json.Marshal(shared.Envelope{Type: msgType, Payload: payload})
+    if err != nil {
+        t.Fatalf("marshal: %v", err)
+    }
+    if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+        t.Fatalf("write: %v", err)
+    }
+}
+
+// menuNoise are envelopes a client sitting in the menu room can receive at
+// any time relative to whatever it's waiting for next - the initial
+// lobby_list on registration, and lobby_list_delta for every other
+// lobby's create/update/remove while it's still in the menu.
+var menuNoise = map[shared.MessageType]bool{
+    shared.MsgLobbyList:      true,
+    shared.MsgLobbyListDelta: true,
+}
+
+// readUntil reads envelopes off conn until one of type want arrives,
+// discarding menu noise along the way.
+func readUntil(t *testing.T, conn *websocket.Conn, want shared.MessageType, out any) {
+    t.Helper()
+
+    for i := 0; i < 10; i++ {
+        if msgType := readEnvelope(t, conn, out); msgType == want {
+            return
+        } else if !menuNoise[msgType] {
+            t.Fatalf("got unexpected envelope %s while waiting for %s", msgType, want)
+        }
+    }
+    t.Fatalf("did not see %s within 10 reads", want)
+}
+
+// TestReconnectResumesLobbySlot drives the actual GET /ws?token=... path
+// end to end (verifySessionToken -> reclaimReconnect -> reattach), rather
+// than just the evictOnDisconnect/reclaimReconnect bookkeeping: a client
+// creates a lobby, drops its connection, and reconnects with the session
+// token the server handed it, and should come back as the same client id,
+// still seated as host of its lobby.
+func TestReconnectResumesLobbySlot(t *testing.T) {
+    hub := NewHub(0)
+    go hub.Run()
+
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        serveWs(hub, w, r)
+    }))
+    defer srv.Close()
+
+    host, hostSession := dialWs(t, srv, "")
+    defer host.Close()
+
+    guest, _ := dialWs(t, srv, "")
+    defer guest.Close()
+
+    sendEnvelope(t, host, shared.MsgCreateLobby, shared.CreateLobbyPayload{Name: "reconnect test"})
+    var lobbyState shared.LobbyStatePayload
+    readUntil(t, host, shared.MsgLobbyState, &lobbyState)
+
+    sendEnvelope(t, guest, shared.MsgJoinLobby, shared.JoinLobbyPayload{LobbyID: lobbyState.LobbyID})
+    var guestState shared.LobbyStatePayload
+    readUntil(t, guest, shared.MsgLobbyState, &guestState)
+    readUntil(t, host, shared.MsgPlayerJoined, nil)
+
+    host.Close()
+
+    // The disconnect is handled asynchronously by the hub goroutine; wait
+    // for it to clear the client before reconnecting, same as a real client
+    // would only retry after its socket actually drops.
+    deadline := time.Now().Add(2 * time.Second)
+    for hub.isLive(hostSession.ClientID) && time.Now().Before(deadline) {
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    reconnected, newSession := dialWs(t, srv, hostSession.Token)
+    defer reconnected.Close()
+
+    if newSession.ClientID != hostSession.ClientID {
+        t.Fatalf("reconnect got client id %s, want original %s", newSession.ClientID, hostSession.ClientID)
+    }
+
+    // reattachPlayer re-sends lobby state to everyone seated, including the
+    // reconnected host, proving it came back into the lobby rather than the
+    // menu.
+    var resumed shared.LobbyStatePayload
+    if msgType := readEnvelope(t, reconnected, &resumed); msgType != shared.MsgLobbyState {
+        t.Fatalf("reconnected host: expected lobby_state, got %s", msgType)
+    }
+    if resumed.LobbyID != lobbyState.LobbyID || !resumed.YouAreHost {
+        t.Fatalf("expected reconnected client to resume as host of %s, got %+v", lobbyState.LobbyID, resumed)
+    }
+}
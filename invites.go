@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// inviteTTL is how long an invite to a private lobby stays valid.
+const inviteTTL = 60 * time.Second
+
+type inviteEntry struct {
+    lobbyID   string
+    expiresAt time.Time
+}
+
+// Invites tracks outstanding private-lobby invites so a target client can
+// join without knowing the lobby's passphrase.
+type Invites struct {
+    mu      sync.Mutex
+    pending map[string]inviteEntry // target client id -> invite
+}
+
+func NewInvites() *Invites {
+    return &Invites{pending: make(map[string]inviteEntry)}
+}
+
+// Add records an invite for targetID to join lobbyID.
+func (iv *Invites) Add(targetID, lobbyID string) {
+    iv.mu.Lock()
+    defer iv.mu.Unlock()
+    iv.pending[targetID] = inviteEntry{lobbyID: lobbyID, expiresAt: time.Now().Add(inviteTTL)}
+}
+
+// Check reports whether targetID currently has a live, unexpired invite to
+// lobbyID, without consuming it.
+func (iv *Invites) Check(targetID, lobbyID string) bool {
+    iv.mu.Lock()
+    defer iv.mu.Unlock()
+
+    entry, ok := iv.pending[targetID]
+    return ok && entry.lobbyID == lobbyID && time.Now().Before(entry.expiresAt)
+}
+
+// Consume checks and clears an invite in one step, so each invite can only
+// be used once.
+func (iv *Invites) Consume(targetID, lobbyID string) bool {
+    iv.mu.Lock()
+    defer iv.mu.Unlock()
+
+    entry, ok := iv.pending[targetID]
+    if !ok || entry.lobbyID != lobbyID || time.Now().After(entry.expiresAt) {
+        return false
+    }
+    delete(iv.pending, targetID)
+    return true
+}
+
+// Invite records a host's invite of targetID into lobbyID.
+func (h *Hub) Invite(targetID, lobbyID string) {
+    h.invites.Add(targetID, lobbyID)
+}
+
+// HasInvite reports whether targetID has a pending invite to lobbyID.
+func (h *Hub) HasInvite(targetID, lobbyID string) bool {
+    return h.invites.Check(targetID, lobbyID)
+}
+
+// ConsumeInvite checks and clears a pending invite in one step.
+func (h *Hub) ConsumeInvite(targetID, lobbyID string) bool {
+    return h.invites.Consume(targetID, lobbyID)
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+    "errors"
+    "sync"
+
+    "tankgame/shared"
+)
+
+const maxPlayersPerLobby = 4
+
+type GameState string
+const(
+	waiting GameState = "waiting"
+	countDown GameState = "starting game"
+	inGame 	GameState = "playing"
+	
+)
+type Lobby struct {
+    id     string
+    name   string
+    hostID string // Player who created it (can start game)
+    state  GameState// True once game starts
+
+    private    bool   // unlisted: omitted from MsgLobbyList, joined by code or invite
+    passphrase string // required to addPlayer when private, unless invited
+
+    allowSpectators bool                // host's choice at creation time
+    spectators      map[string]*Client  // playerID -> Client, watch-only
+
+    players map[string]*Client // playerID -> Client
+    ready   map[string]bool    // playerID -> ready status
+    mu      sync.RWMutex       // Protects players, ready and spectators maps
+
+    session *GameSession // non-nil while state == inGame
+
+    hub *Hub // Reference back to hub (to remove self when empty)
+}
+
+func NewLobby(id, name string, host *Client, hub *Hub, private bool, passphrase string, allowSpectators bool) *Lobby {
+    l := &Lobby{
+        id:              id,
+        name:            name,
+        hostID:          host.id,
+        private:         private,
+        passphrase:      passphrase,
+        allowSpectators: allowSpectators,
+        spectators:      make(map[string]*Client),
+        players:         make(map[string]*Client),
+        ready:           make(map[string]bool),
+        state:           waiting,
+        hub:             hub,
+    }
+    // Host is automatically in the lobby
+    l.players[host.id] = host
+    l.ready[host.id] = false
+    return l
+}
+
+// isHost reports whether clientID is this lobby's current host.
+func (l *Lobby) isHost(clientID string) bool {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    return l.hostID == clientID
+}
+
+// passphraseMatches reports whether passphrase is this lobby's configured
+// code. A lobby created with no passphrase never matches one, so it's only
+// joinable via an invite rather than by everyone who simply omits the field.
+func (l *Lobby) passphraseMatches(passphrase string) bool {
+    return l.passphrase != "" && passphrase == l.passphrase
+}
+
+func (l *Lobby) addPlayer(c *Client, passphrase string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if l.state != waiting {
+        return errors.New("game already in progress")
+    }
+
+    if len(l.players) >= maxPlayersPerLobby {
+        return errors.New("lobby is full")
+    }
+
+    if _, exists := l.players[c.id]; exists {
+        return errors.New("already in this lobby")
+    }
+
+    if l.private && !l.passphraseMatches(passphrase) && !l.hub.ConsumeInvite(c.id, l.id) {
+        return errors.New("invalid passphrase")
+    }
+
+    // Add the player
+    l.players[c.id] = c
+    l.ready[c.id] = false
+
+    // Tell everyone already in the lobby that someone joined
+    l.broadcastUnlocked(shared.Envelope{
+        Type: shared.MsgPlayerJoined,
+        Payload: shared.PlayerJoinedPayload{
+            Player: shared.PlayerInfo{
+                ID:     c.id,
+                Name:   c.name,
+                Ready:  false,
+                IsHost: false,
+            },
+        },
+    })
+
+    // Send lobby state to the new player
+    l.sendStateToClientUnlocked(c)
+
+    l.hub.LeaveRoom(c, menuRoom)
+    l.hub.JoinRoom(c, lobbyRoom(l.id))
+    if !l.private {
+        l.hub.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaUpdate, Lobby: l.infoUnlocked()},
+        })
+    }
+
+    return nil
+}
+func (l *Lobby) removePlayer(c *Client) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if _, exists := l.players[c.id]; !exists {
+        return // Not in this lobby
+    }
+
+    delete(l.players, c.id)
+    delete(l.ready, c.id)
+
+    l.hub.LeaveRoom(c, lobbyRoom(l.id))
+    l.hub.JoinRoom(c, menuRoom)
+
+    // A mid-game disconnect kills their tank but the match carries on.
+    if l.session != nil {
+        l.session.MarkDisconnected(c.id)
+    }
+
+    // Only tear the lobby down once nobody at all is left in it - a
+    // lingering spectator keeps it alive.
+    if len(l.players) == 0 && len(l.spectators) == 0 {
+        if l.session != nil {
+            l.session.Stop()
+            l.session = nil
+        }
+        l.hub.removeLobby(l.id)
+        return
+    }
+
+    // If the host left, pick a new host
+    if l.hostID == c.id {
+        for id := range l.players {
+            l.hostID = id // Just pick the first one
+            break
+        }
+    }
+
+    // Tell remaining players someone left
+    l.broadcastUnlocked(shared.Envelope{
+        Type:    shared.MsgPlayerLeft,
+        Payload: shared.PlayerLeftPayload{PlayerID: c.id},
+    })
+
+    if !l.private {
+        l.hub.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaUpdate, Lobby: l.infoUnlocked()},
+        })
+    }
+
+    // Send updated state (in case host changed)
+    l.sendStateToAllUnlocked()
+}
+
+// leave removes c from the lobby whether it was seated as a player or just
+// spectating, and routes to the right cleanup for each.
+func (l *Lobby) leave(c *Client) {
+    l.mu.RLock()
+    _, spectating := l.spectators[c.id]
+    l.mu.RUnlock()
+
+    if spectating {
+        l.removeSpectator(c)
+        return
+    }
+    l.removePlayer(c)
+}
+
+// isSpectator reports whether clientID is watching this lobby rather than
+// playing in it.
+func (l *Lobby) isSpectator(clientID string) bool {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    _, ok := l.spectators[clientID]
+    return ok
+}
+
+// addSpectator seats an extra connection as a watch-only spectator: it
+// receives lobby state and gameplay broadcasts but never appears in
+// PlayerInfo and its input is ignored.
+func (l *Lobby) addSpectator(c *Client, passphrase string) error {
+    l.mu.Lock()
+
+    if !l.allowSpectators {
+        l.mu.Unlock()
+        return errors.New("spectators are not allowed in this lobby")
+    }
+    if l.private && !l.passphraseMatches(passphrase) && !l.hub.ConsumeInvite(c.id, l.id) {
+        l.mu.Unlock()
+        return errors.New("invalid passphrase")
+    }
+    if _, exists := l.players[c.id]; exists {
+        l.mu.Unlock()
+        return errors.New("already a player in this lobby")
+    }
+    if _, exists := l.spectators[c.id]; exists {
+        l.mu.Unlock()
+        return errors.New("already spectating this lobby")
+    }
+
+    l.spectators[c.id] = c
+    l.sendStateToClientUnlocked(c)
+    info := l.infoUnlocked()
+    l.mu.Unlock()
+
+    l.hub.LeaveRoom(c, menuRoom)
+    l.hub.JoinRoom(c, lobbyRoom(l.id))
+
+    if !l.private {
+        l.hub.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaUpdate, Lobby: info},
+        })
+    }
+
+    return nil
+}
+
+// removeSpectator drops a spectator from the lobby. It only tears the
+// lobby down if no players are left seated either.
+func (l *Lobby) removeSpectator(c *Client) {
+    l.mu.Lock()
+    if _, exists := l.spectators[c.id]; !exists {
+        l.mu.Unlock()
+        return
+    }
+    delete(l.spectators, c.id)
+    empty := len(l.players) == 0 && len(l.spectators) == 0
+    info := l.infoUnlocked()
+    l.mu.Unlock()
+
+    l.hub.LeaveRoom(c, lobbyRoom(l.id))
+    l.hub.JoinRoom(c, menuRoom)
+
+    if !empty && !l.private {
+        l.hub.BroadcastRoom(menuRoom, shared.Envelope{
+            Type:    shared.MsgLobbyListDelta,
+            Payload: shared.LobbyListDeltaPayload{Op: shared.LobbyDeltaUpdate, Lobby: info},
+        })
+    }
+
+    if !empty {
+        return
+    }
+
+    l.mu.Lock()
+    if l.session != nil {
+        l.session.Stop()
+        l.session = nil
+    }
+    l.mu.Unlock()
+    l.hub.removeLobby(l.id)
+}
+
+// Info returns a summary for the lobby list (shown in menu)
+func (l *Lobby) Info() shared.LobbyInfo {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    return l.infoUnlocked()
+}
+
+// infoUnlocked is Info without the lock, for callers that already hold l.mu.
+func (l *Lobby) infoUnlocked() shared.LobbyInfo {
+    return shared.LobbyInfo{
+        ID:             l.id,
+        Name:           l.name,
+        PlayerCount:    len(l.players),
+        MaxPlayers:     maxPlayersPerLobby,
+        InGame:         l.state != waiting,
+        Private:        l.private,
+        SpectatorCount: len(l.spectators),
+    }
+}
+
+// sendStateToAll sends full lobby state to every player
+func (l *Lobby) sendStateToAll() {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    l.sendStateToAllUnlocked()
+}
+
+func (l *Lobby) sendStateToAllUnlocked() {
+    for _, client := range l.players {
+        l.sendStateToClientUnlocked(client)
+    }
+    for _, client := range l.spectators {
+        l.sendStateToClientUnlocked(client)
+    }
+}
+
+func (l *Lobby) sendStateToClientUnlocked(c *Client) {
+    players := make([]shared.PlayerInfo, 0, len(l.players))
+    for id, client := range l.players {
+        players = append(players, shared.PlayerInfo{
+            ID:     id,
+            Name:   client.name,
+            Ready:  l.ready[id],
+            IsHost: id == l.hostID,
+        })
+    }
+
+    c.sendEnvelope(shared.Envelope{
+        Type: shared.MsgLobbyState,
+        Payload: shared.LobbyStatePayload{
+            LobbyID:    l.id,
+            LobbyName:  l.name,
+            Players:    players,
+            YouAreHost: c.id == l.hostID,
+        },
+    })
+}
+
+// reattachPlayer swaps in a reconnected client's fresh connection for an
+// existing lobby slot, leaving ready status and hostID untouched, and
+// re-sends the lobby state so the client catches up on anything it missed.
+func (l *Lobby) reattachPlayer(c *Client) {
+    l.mu.Lock()
+    if _, ok := l.players[c.id]; !ok {
+        l.mu.Unlock()
+        return
+    }
+    l.players[c.id] = c
+    l.mu.Unlock()
+
+    l.hub.LeaveRoom(c, menuRoom)
+    l.hub.JoinRoom(c, lobbyRoom(l.id))
+
+    l.sendStateToAll()
+}
+
+// reattachSpectator is reattachPlayer's counterpart for a reconnecting
+// spectator: it swaps in the fresh connection for the stale one left in
+// l.spectators, rejoins the lobby room, and re-sends state. Without this,
+// a reconnecting spectator's old, closed-channel *Client stays registered
+// and the next sendStateToAll panics trying to write to it.
+func (l *Lobby) reattachSpectator(c *Client) {
+    l.mu.Lock()
+    if _, ok := l.spectators[c.id]; !ok {
+        l.mu.Unlock()
+        return
+    }
+    l.spectators[c.id] = c
+    l.mu.Unlock()
+
+    l.hub.LeaveRoom(c, menuRoom)
+    l.hub.JoinRoom(c, lobbyRoom(l.id))
+
+    l.sendStateToAll()
+}
+
+// broadcastUnlocked fans an envelope out to this lobby's room. It no longer
+// needs l.mu itself (the room membership lives in the hub's Broadcaster),
+// but callers still hold the lock for the player/ready bookkeeping around it.
+func (l *Lobby) broadcastUnlocked(env shared.Envelope) {
+    l.hub.BroadcastRoom(lobbyRoom(l.id), env)
+}
+
+// broadcastAll is the unlocked-free equivalent of broadcastUnlocked, for
+// callers outside the lobby (e.g. the GameSession tick loop).
+func (l *Lobby) broadcastAll(env shared.Envelope) {
+    l.broadcastUnlocked(env)
+}
+
+// setPlayerReady updates a player's ready flag and re-broadcasts lobby state.
+func (l *Lobby) setPlayerReady(c *Client, ready bool) {
+    l.mu.Lock()
+    if _, ok := l.players[c.id]; !ok {
+        l.mu.Unlock()
+        return
+    }
+    l.ready[c.id] = ready
+    l.mu.Unlock()
+
+    l.sendStateToAll()
+}
+
+// activeSession returns the lobby's running GameSession, or nil if no game
+// is in progress.
+func (l *Lobby) activeSession() *GameSession {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    return l.session
+}
+
+// tryStart begins the match if the requester is host, the lobby is idle,
+// and every player is ready. On success it spawns a GameSession tick loop
+// and moves the lobby into the inGame state.
+func (l *Lobby) tryStart(c *Client) {
+    l.mu.Lock()
+
+    if c.id != l.hostID {
+        l.mu.Unlock()
+        c.sendError("only the host can start the game")
+        return
+    }
+    if l.state != waiting {
+        l.mu.Unlock()
+        c.sendError("game already starting or in progress")
+        return
+    }
+    if len(l.players) < 2 {
+        l.mu.Unlock()
+        c.sendError("need at least 2 players to start")
+        return
+    }
+    for _, ready := range l.ready {
+        if !ready {
+            l.mu.Unlock()
+            c.sendError("not all players are ready")
+            return
+        }
+    }
+
+    playerIDs := make([]string, 0, len(l.players))
+    for id := range l.players {
+        playerIDs = append(playerIDs, id)
+    }
+
+    l.state = inGame
+    session := NewGameSession(l, playerIDs)
+    l.session = session
+
+    for _, client := range l.players {
+        client.sendEnvelope(shared.Envelope{
+            Type:    shared.MsgGameStarting,
+            Payload: shared.GameStartingPayload{YourTankID: client.id},
+        })
+    }
+    l.mu.Unlock()
+
+    go session.Run()
+}
+
+// endGame is called by the GameSession once a single tank remains. It
+// resets the lobby to waiting (clearing ready flags) so players can go
+// again, and announces the winner.
+func (l *Lobby) endGame(winnerID string) {
+    l.mu.Lock()
+    l.state = waiting
+    l.session = nil
+    for id := range l.ready {
+        l.ready[id] = false
+    }
+    l.mu.Unlock()
+
+    l.broadcastAll(shared.Envelope{
+        Type:    shared.MsgGameOver,
+        Payload: shared.GameOverPayload{WinnerID: winnerID},
+    })
+    l.sendStateToAll()
+}
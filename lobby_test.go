@@ -0,0 +1,107 @@
+package main
+
+import "testing"
+
+// TestAddPlayerRejectsWrongPassphrase checks a private lobby refuses a
+// join attempt with the wrong passphrase.
+func TestAddPlayerRejectsWrongPassphrase(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    guest := newTestClient("guest")
+
+    lobby := hub.createLobby("private", host, true, "secret", false)
+
+    if err := lobby.addPlayer(guest, "wrong"); err == nil {
+        t.Fatalf("expected addPlayer to reject a wrong passphrase")
+    }
+    if _, joined := lobby.players[guest.id]; joined {
+        t.Fatalf("guest should not have been seated with a wrong passphrase")
+    }
+}
+
+// TestAddPlayerRejectsEmptyPassphraseOnPrivateLobby checks a private lobby
+// never matches an empty passphrase against its own empty passphrase - it
+// must be invite-only if the host never set one.
+func TestAddPlayerRejectsEmptyPassphraseOnPrivateLobby(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    guest := newTestClient("guest")
+
+    lobby := hub.createLobby("private", host, true, "", false)
+
+    if err := lobby.addPlayer(guest, ""); err == nil {
+        t.Fatalf("expected addPlayer to reject an empty passphrase against a private, invite-only lobby")
+    }
+}
+
+// TestAddPlayerAcceptsCorrectPassphrase checks the happy path: the right
+// passphrase seats the joining player.
+func TestAddPlayerAcceptsCorrectPassphrase(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    guest := newTestClient("guest")
+
+    lobby := hub.createLobby("private", host, true, "secret", false)
+
+    if err := lobby.addPlayer(guest, "secret"); err != nil {
+        t.Fatalf("addPlayer with correct passphrase: %v", err)
+    }
+    if _, joined := lobby.players[guest.id]; !joined {
+        t.Fatalf("expected guest to be seated with the correct passphrase")
+    }
+}
+
+// TestAddPlayerAcceptsConsumedInvite checks an invited player can join a
+// private lobby with no passphrase at all, and that the invite is
+// single-use: a second join attempt without the passphrase fails.
+func TestAddPlayerAcceptsConsumedInvite(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    guest := newTestClient("guest")
+
+    lobby := hub.createLobby("private", host, true, "secret", false)
+    hub.Invite(guest.id, lobby.id)
+
+    if err := lobby.addPlayer(guest, ""); err != nil {
+        t.Fatalf("addPlayer with a pending invite: %v", err)
+    }
+
+    lobby.removePlayer(guest)
+    if err := lobby.addPlayer(guest, ""); err == nil {
+        t.Fatalf("expected the invite to be consumed after one join")
+    }
+}
+
+// TestAddSpectatorRejectsWhenDisallowed checks a lobby created with
+// allowSpectators=false refuses spectators even with no passphrase set.
+func TestAddSpectatorRejectsWhenDisallowed(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    spectator := newTestClient("spectator")
+
+    lobby := hub.createLobby("no spectators", host, false, "", false)
+
+    if err := lobby.addSpectator(spectator, ""); err == nil {
+        t.Fatalf("expected addSpectator to reject a lobby with allowSpectators=false")
+    }
+}
+
+// TestAddSpectatorGatedByPassphrase checks a private lobby's spectator path
+// is gated by the same passphrase as addPlayer.
+func TestAddSpectatorGatedByPassphrase(t *testing.T) {
+    hub := NewHub(0)
+    host := newTestClient("host")
+    spectator := newTestClient("spectator")
+
+    lobby := hub.createLobby("private", host, true, "secret", true)
+
+    if err := lobby.addSpectator(spectator, "wrong"); err == nil {
+        t.Fatalf("expected addSpectator to reject a wrong passphrase")
+    }
+    if err := lobby.addSpectator(spectator, "secret"); err != nil {
+        t.Fatalf("addSpectator with correct passphrase: %v", err)
+    }
+    if _, watching := lobby.spectators[spectator.id]; !watching {
+        t.Fatalf("expected spectator to be seated with the correct passphrase")
+    }
+}
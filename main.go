@@ -5,14 +5,33 @@ import (
     "encoding/hex"
     "log"
     "net/http"
+    "os"
+    "strconv"
+    "time"
 
     "github.com/gorilla/websocket"
+    "tankgame/shared"
+    "tankgame/shared/wire"
 )
 
 var upgrader = websocket.Upgrader{
     ReadBufferSize:  1024,
     WriteBufferSize: 1024,
     CheckOrigin:     func(r *http.Request) bool { return true }, // Allow all origins (dev only!)
+    // Binary listed first: a client that understands both should prefer it.
+    // Browsers that never set Sec-WebSocket-Protocol fall back to JSON.
+    Subprotocols: []string{wire.NewBinaryCodec().Name(), shared.JSONCodec{}.Name()},
+}
+
+// negotiatedCodec picks the Codec matching whatever subprotocol the
+// upgrade settled on, defaulting to JSON for clients that didn't ask. A
+// fresh BinaryCodec is built per connection since it carries gob stream
+// state that can't be shared across sockets.
+func negotiatedCodec(conn *websocket.Conn) shared.Codec {
+    if conn.Subprotocol() == wire.NewBinaryCodec().Name() {
+        return wire.NewBinaryCodec()
+    }
+    return shared.JSONCodec{}
 }
 
 // generateID creates a random hex string for IDs
@@ -22,7 +41,9 @@ func generateID() string {
     return hex.EncodeToString(b)
 }
 
-// serveWs handles a new WebSocket connection
+// serveWs handles a new WebSocket connection. A client that already has a
+// session token from a previous connect can pass it as ?token=... to
+// resume its place in a lobby instead of starting over.
 func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
     // Upgrade HTTP connection to WebSocket
     conn, err := upgrader.Upgrade(w, r, nil)
@@ -31,19 +52,92 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    if token := r.URL.Query().Get("token"); token != "" {
+        reconnectWs(hub, conn, token)
+        return
+    }
+
     // Create client, register with hub
-    client := NewClient(generateID(), hub, conn)
+    client := NewClient(generateID(), hub, conn, negotiatedCodec(conn))
     hub.register <- client
 
     log.Printf("client connected: %s", client.id)
+    client.sendEnvelope(shared.Envelope{
+        Type: shared.MsgSession,
+        Payload: shared.SessionPayload{
+            ClientID: client.id,
+            Token:    hub.newSessionToken(client.id),
+        },
+    })
 
     // Start the pumps (each in their own goroutine)
     go client.writePump()
     go client.readPump() // This one blocks until disconnect
 }
 
+// reconnectWs restores a client that dropped its connection but reconnected
+// within the grace period, reusing its id and re-attaching it to its old
+// lobby. A duplicate reconnect while the original socket is still live is
+// rejected so a stale token can't kick the real player.
+func reconnectWs(hub *Hub, conn *websocket.Conn, token string) {
+    clientID, ok := hub.verifySessionToken(token)
+    if !ok {
+        log.Println("reconnect rejected: invalid token")
+        conn.Close()
+        return
+    }
+
+    if hub.isLive(clientID) {
+        log.Printf("reconnect rejected: %s already connected", clientID)
+        conn.Close()
+        return
+    }
+
+    old, ok := hub.reclaimReconnect(clientID)
+    if !ok {
+        log.Printf("reconnect rejected: no pending session for %s", clientID)
+        conn.Close()
+        return
+    }
+
+    client := old.reattach(hub, conn, negotiatedCodec(conn))
+    hub.register <- client
+
+    log.Printf("client reconnected: %s", client.id)
+    client.sendEnvelope(shared.Envelope{
+        Type: shared.MsgSession,
+        Payload: shared.SessionPayload{
+            ClientID: client.id,
+            Token:    hub.newSessionToken(client.id),
+        },
+    })
+
+    go client.writePump()
+    go client.readPump()
+
+    if lobby := client.currentLobby(); lobby != nil {
+        if lobby.isSpectator(client.id) {
+            lobby.reattachSpectator(client)
+        } else {
+            lobby.reattachPlayer(client)
+        }
+    }
+}
+
+// reconnectGraceFromEnv reads the reconnect grace period from
+// TANKS_RECONNECT_GRACE_SECONDS so deployments can tune it without a
+// rebuild, falling back to defaultReconnectGracePeriod if it's unset or
+// invalid.
+func reconnectGraceFromEnv() time.Duration {
+    secs, err := strconv.Atoi(os.Getenv("TANKS_RECONNECT_GRACE_SECONDS"))
+    if err != nil || secs <= 0 {
+        return defaultReconnectGracePeriod
+    }
+    return time.Duration(secs) * time.Second
+}
+
 func main() {
-    hub := NewHub()
+    hub := NewHub(reconnectGraceFromEnv())
     go hub.Run() // Start hub in background
 
     http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,40 @@
+package shared
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+// Codec encodes and decodes Envelopes for one wire subprotocol, so Client
+// doesn't have to hard-code json.Marshal/Unmarshal against the connection.
+type Codec interface {
+    // Name is the Sec-WebSocket-Protocol value this codec negotiates.
+    Name() string
+
+    Encode(Envelope) ([]byte, error)
+    Decode([]byte) (Envelope, error)
+
+    // FrameType is the gorilla/websocket message type Encode's output
+    // should be sent as (TextMessage for JSON, BinaryMessage for binary).
+    FrameType() int
+}
+
+// JSONCodec is the original wire format, kept as the fallback for any
+// client that doesn't negotiate a subprotocol (e.g. a plain browser
+// WebSocket that never set Sec-WebSocket-Protocol).
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "tanks.v1.json" }
+
+func (JSONCodec) Encode(env Envelope) ([]byte, error) {
+    return json.Marshal(env)
+}
+
+func (JSONCodec) Decode(data []byte) (Envelope, error) {
+    var env Envelope
+    err := json.Unmarshal(data, &env)
+    return env, err
+}
+
+func (JSONCodec) FrameType() int { return websocket.TextMessage }
@@ -0,0 +1,184 @@
+package shared
+
+// MessageType identifies what kind of message this is
+type MessageType string
+
+const (
+    // Client -> Server 
+    MsgRequestLobbies MessageType = "request_lobbies"
+    MsgCreateLobby    MessageType = "create_lobby"
+    MsgJoinLobby      MessageType = "join_lobby"
+    MsgLeaveLobby     MessageType = "leave_lobby"
+    MsgSetReady       MessageType = "set_ready"
+    MsgStartGame      MessageType = "start_game"
+    MsgInput          MessageType = "input"
+    MsgTankHit        MessageType = "tank_hit"
+    MsgInvitePlayer   MessageType = "invite_player"
+    MsgAcceptInvite   MessageType = "accept_invite"
+    MsgJoinAsSpectator MessageType = "join_as_spectator"
+
+    // Server -> Client
+    MsgLobbyList    MessageType = "lobby_list"
+    MsgLobbyState   MessageType = "lobby_state"
+    MsgPlayerJoined MessageType = "player_joined"
+    MsgPlayerLeft   MessageType = "player_left"
+    MsgError        MessageType = "error"
+    MsgGameStarting   MessageType = "game_starting"
+    MsgSession        MessageType = "session"
+    MsgWorldSnapshot  MessageType = "world_snapshot"
+    MsgGameOver       MessageType = "game_over"
+    MsgLobbyListDelta MessageType = "lobby_list_delta"
+    MsgInviteReceived MessageType = "invite_received"
+)
+
+// LobbyListDeltaOp identifies what changed about a lobby in a
+// MsgLobbyListDelta, so the menu can patch its list instead of replacing it.
+type LobbyListDeltaOp string
+
+const (
+    LobbyDeltaAdd    LobbyListDeltaOp = "add"
+    LobbyDeltaRemove LobbyListDeltaOp = "remove"
+    LobbyDeltaUpdate LobbyListDeltaOp = "update"
+)
+
+// Envelope wraps all messages - this is what actually goes over the wire
+type Envelope struct {
+    Type    MessageType `json:"type"`
+    Payload any         `json:"payload,omitempty"`
+}
+// === Payloads for Client -> Server ===
+
+type CreateLobbyPayload struct {
+    Name            string `json:"name"`
+    Private         bool   `json:"private"`
+    Passphrase      string `json:"passphrase,omitempty"`
+    AllowSpectators bool   `json:"allow_spectators"`
+}
+
+type JoinLobbyPayload struct {
+    LobbyID    string `json:"lobby_id"`
+    Passphrase string `json:"passphrase,omitempty"`
+}
+
+// InvitePlayerPayload is sent by a lobby's host to invite a specific player;
+// the hub rejects it from anyone else.
+type InvitePlayerPayload struct {
+    TargetID string `json:"target_id"`
+}
+
+// AcceptInvitePayload lets an invited player join a private lobby without
+// knowing its passphrase.
+type AcceptInvitePayload struct {
+    LobbyID string `json:"lobby_id"`
+}
+
+type SetReadyPayload struct {
+    Ready bool `json:"ready"`
+}
+
+// InputPayload is sent every client tick once a game is running: a
+// movement axis plus whether the fire button is held, tagged with the
+// client's own tick number so it can reconcile the authoritative snapshot.
+type InputPayload struct {
+    MoveX      float64 `json:"move_x"`
+    MoveY      float64 `json:"move_y"`
+    Fire       bool    `json:"fire"`
+    ClientTick uint32  `json:"client_tick"`
+}
+
+// TankHitPayload reports a client-observed hit for the server to apply
+// authoritatively; the server is free to ignore implausible reports.
+type TankHitPayload struct {
+    TargetID string `json:"target_id"`
+    Damage   int    `json:"damage"`
+}
+
+// === Payloads for Server -> Client ===
+
+type LobbyInfo struct {
+    ID          string `json:"id"`
+    Name        string `json:"name"`
+    PlayerCount int    `json:"player_count"`
+    MaxPlayers  int    `json:"max_players"`
+    InGame         bool `json:"in_game"`
+    Private        bool `json:"private"`
+    SpectatorCount int  `json:"spectator_count"`
+}
+
+type LobbyListPayload struct {
+    Lobbies []LobbyInfo `json:"lobbies"`
+}
+
+// LobbyListDeltaPayload is pushed to the menu room whenever a lobby is
+// created, removed, or changes in a way the menu list should reflect (e.g.
+// player count). For a remove op only Lobby.ID is populated.
+type LobbyListDeltaPayload struct {
+    Op    LobbyListDeltaOp `json:"op"`
+    Lobby LobbyInfo        `json:"lobby"`
+}
+
+type PlayerInfo struct {
+    ID     string `json:"id"`
+    Name   string `json:"name"`
+    Ready  bool   `json:"ready"`
+    IsHost bool   `json:"is_host"`
+}
+
+type LobbyStatePayload struct {
+    LobbyID    string       `json:"lobby_id"`
+    LobbyName  string       `json:"lobby_name"`
+    Players    []PlayerInfo `json:"players"`
+    YouAreHost bool         `json:"you_are_host"`
+}
+
+type PlayerJoinedPayload struct {
+    Player PlayerInfo `json:"player"`
+}
+
+type PlayerLeftPayload struct {
+    PlayerID string `json:"player_id"`
+}
+
+type ErrorPayload struct {
+    Message string `json:"message"`
+}
+
+type GameStartingPayload struct {
+    YourTankID string `json:"your_tank_id"`
+}
+
+// SessionPayload is sent right after a connection is established (fresh or
+// reconnected) so the client can hold onto a token for reconnecting later.
+type SessionPayload struct {
+    ClientID string `json:"client_id"`
+    Token    string `json:"token"`
+}
+
+// TankSnapshot is one tank's authoritative state at a given tick.
+type TankSnapshot struct {
+    ID   string  `json:"id"`
+    X    float64 `json:"x"`
+    Y    float64 `json:"y"`
+    HP   int     `json:"hp"`
+    Dead bool    `json:"dead"`
+}
+
+// WorldSnapshotPayload is broadcast to every client in a running game on
+// each tick of the authoritative simulation.
+type WorldSnapshotPayload struct {
+    Tick  uint64         `json:"tick"`
+    Tanks []TankSnapshot `json:"tanks"`
+}
+
+// GameOverPayload announces the end of a match once a single tank remains.
+type GameOverPayload struct {
+    WinnerID string `json:"winner_id"`
+}
+
+// InviteReceivedPayload is delivered to an invited client wherever they are
+// (menu or another lobby), letting them MsgAcceptInvite without the code.
+type InviteReceivedPayload struct {
+    LobbyID   string `json:"lobby_id"`
+    LobbyName string `json:"lobby_name"`
+    FromName  string `json:"from_name"`
+}
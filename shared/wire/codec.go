@@ -0,0 +1,170 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"tankgame/shared"
+)
+
+// typeIDs assigns a stable uint16 to each MessageType for the binary
+// envelope. This list is schema v1: append new message types to the end,
+// never reuse or renumber an id, so old and new builds stay wire-compatible.
+var typeIDs = map[shared.MessageType]uint16{
+    shared.MsgRequestLobbies:  1,
+    shared.MsgCreateLobby:     2,
+    shared.MsgJoinLobby:       3,
+    shared.MsgLeaveLobby:      4,
+    shared.MsgSetReady:        5,
+    shared.MsgStartGame:       6,
+    shared.MsgLobbyList:       7,
+    shared.MsgLobbyState:      8,
+    shared.MsgPlayerJoined:    9,
+    shared.MsgPlayerLeft:      10,
+    shared.MsgError:           11,
+    shared.MsgGameStarting:    12,
+    shared.MsgSession:         13,
+    shared.MsgInput:           14,
+    shared.MsgTankHit:         15,
+    shared.MsgWorldSnapshot:   16,
+    shared.MsgGameOver:        17,
+    shared.MsgLobbyListDelta:  18,
+    shared.MsgInvitePlayer:    19,
+    shared.MsgAcceptInvite:    20,
+    shared.MsgInviteReceived:  21,
+    shared.MsgJoinAsSpectator: 22,
+}
+
+var messageTypes = func() map[uint16]shared.MessageType {
+    m := make(map[uint16]shared.MessageType, len(typeIDs))
+    for t, id := range typeIDs {
+        m[id] = t
+    }
+    return m
+}()
+
+// payloadFactories returns a fresh pointer to the payload type a message
+// type gob-decodes into. Messages with no entry here (and no payload on
+// the wire) decode to a nil Payload. This is what lets DecodeEnvelope hand
+// back a properly typed payload instead of the map[string]any JSON leaves
+// handlers to re-marshal.
+var payloadFactories = map[shared.MessageType]func() any{
+    shared.MsgCreateLobby:     func() any { return new(shared.CreateLobbyPayload) },
+    shared.MsgJoinLobby:       func() any { return new(shared.JoinLobbyPayload) },
+    shared.MsgSetReady:        func() any { return new(shared.SetReadyPayload) },
+    shared.MsgLobbyList:       func() any { return new(shared.LobbyListPayload) },
+    shared.MsgLobbyState:      func() any { return new(shared.LobbyStatePayload) },
+    shared.MsgPlayerJoined:    func() any { return new(shared.PlayerJoinedPayload) },
+    shared.MsgPlayerLeft:      func() any { return new(shared.PlayerLeftPayload) },
+    shared.MsgError:           func() any { return new(shared.ErrorPayload) },
+    shared.MsgGameStarting:    func() any { return new(shared.GameStartingPayload) },
+    shared.MsgSession:         func() any { return new(shared.SessionPayload) },
+    shared.MsgInput:           func() any { return new(shared.InputPayload) },
+    shared.MsgTankHit:         func() any { return new(shared.TankHitPayload) },
+    shared.MsgWorldSnapshot:   func() any { return new(shared.WorldSnapshotPayload) },
+    shared.MsgGameOver:        func() any { return new(shared.GameOverPayload) },
+    shared.MsgLobbyListDelta:  func() any { return new(shared.LobbyListDeltaPayload) },
+    shared.MsgInvitePlayer:    func() any { return new(shared.InvitePlayerPayload) },
+    shared.MsgAcceptInvite:    func() any { return new(shared.AcceptInvitePayload) },
+    shared.MsgInviteReceived:  func() any { return new(shared.InviteReceivedPayload) },
+    shared.MsgJoinAsSpectator: func() any { return new(shared.JoinLobbyPayload) },
+    // MsgRequestLobbies, MsgLeaveLobby and MsgStartGame carry no payload.
+}
+
+// BinaryCodec is the compact, schema-versioned alternative to JSONCodec,
+// negotiated via the "tanks.v1.bin" WebSocket subprotocol. It carries
+// per-connection gob stream state (see encodeEnvelope/decodeEnvelope), so a
+// codec negotiated for one connection must never be reused on another -
+// always build one with NewBinaryCodec per socket.
+type BinaryCodec struct {
+    encMu  sync.Mutex
+    encBuf bytes.Buffer
+    enc    *gob.Encoder
+
+    // decodeEnvelope only ever runs on a connection's own readPump
+    // goroutine, so dec needs no lock of its own.
+    decBuf bytes.Buffer
+    dec    *gob.Decoder
+}
+
+// NewBinaryCodec creates a BinaryCodec with its own gob encoder/decoder
+// pair, ready to negotiate one connection.
+func NewBinaryCodec() *BinaryCodec {
+    bc := &BinaryCodec{}
+    bc.enc = gob.NewEncoder(&bc.encBuf)
+    bc.dec = gob.NewDecoder(&bc.decBuf)
+    return bc
+}
+
+func (*BinaryCodec) Name() string { return "tanks.v1.bin" }
+
+func (bc *BinaryCodec) Encode(env shared.Envelope) ([]byte, error) {
+    return bc.encodeEnvelope(env)
+}
+
+func (bc *BinaryCodec) Decode(data []byte) (shared.Envelope, error) {
+    return bc.decodeEnvelope(data)
+}
+
+func (*BinaryCodec) FrameType() int { return websocket.BinaryMessage }
+
+// encodeEnvelope gob-encodes an envelope's payload and wraps it in a Frame
+// keyed by the message's stable type id. It reuses this codec's own
+// gob.Encoder across every call rather than building a fresh one per
+// message: gob only writes a payload type's descriptor the first time that
+// type crosses the wire, so for the steady 30Hz MsgWorldSnapshot traffic
+// this format exists for, a freshly built encoder per frame would have
+// retransmitted that descriptor on every single tick.
+func (bc *BinaryCodec) encodeEnvelope(env shared.Envelope) ([]byte, error) {
+    id, ok := typeIDs[env.Type]
+    if !ok {
+        return nil, fmt.Errorf("wire: no type id registered for %q", env.Type)
+    }
+
+    bc.encMu.Lock()
+    defer bc.encMu.Unlock()
+
+    bc.encBuf.Reset()
+    if env.Payload != nil {
+        if err := bc.enc.Encode(env.Payload); err != nil {
+            return nil, fmt.Errorf("wire: encode payload for %q: %w", env.Type, err)
+        }
+    }
+
+    return Encode(Frame{Type: id, Payload: bc.encBuf.Bytes()}), nil
+}
+
+// decodeEnvelope parses a Frame and gob-decodes its payload straight into
+// the message type's registered payload struct, using this codec's own
+// gob.Decoder so it learns each payload type's descriptor exactly once,
+// matching its peer's encoder. Frames must arrive in the same order they
+// were encoded in - true for a single WebSocket connection, but decodeEnvelope
+// itself does no reordering.
+func (bc *BinaryCodec) decodeEnvelope(data []byte) (shared.Envelope, error) {
+    frame, err := Decode(data)
+    if err != nil {
+        return shared.Envelope{}, err
+    }
+
+    msgType, ok := messageTypes[frame.Type]
+    if !ok {
+        return shared.Envelope{}, fmt.Errorf("wire: unknown type id %d", frame.Type)
+    }
+
+    env := shared.Envelope{Type: msgType}
+    if factory, ok := payloadFactories[msgType]; ok && len(frame.Payload) > 0 {
+        payload := factory()
+
+        bc.decBuf.Reset()
+        bc.decBuf.Write(frame.Payload)
+        if err := bc.dec.Decode(payload); err != nil {
+            return shared.Envelope{}, fmt.Errorf("wire: decode payload for %q: %w", msgType, err)
+        }
+        env.Payload = payload
+    }
+
+    return env, nil
+}
@@ -0,0 +1,85 @@
+package wire
+
+import (
+    "testing"
+
+    "tankgame/shared"
+)
+
+// TestBinaryCodecRoundTripsPayload checks a payload-bearing envelope comes
+// back out of Decode the same as it went into Encode.
+func TestBinaryCodecRoundTripsPayload(t *testing.T) {
+    enc := NewBinaryCodec()
+    dec := NewBinaryCodec()
+
+    want := shared.CreateLobbyPayload{Name: "test", Private: true, Passphrase: "secret", AllowSpectators: true}
+    data, err := enc.Encode(shared.Envelope{Type: shared.MsgCreateLobby, Payload: want})
+    if err != nil {
+        t.Fatalf("Encode: %v", err)
+    }
+
+    got, err := dec.Decode(data)
+    if err != nil {
+        t.Fatalf("Decode: %v", err)
+    }
+    if got.Type != shared.MsgCreateLobby {
+        t.Fatalf("got type %v, want %v", got.Type, shared.MsgCreateLobby)
+    }
+
+    payload, ok := got.Payload.(*shared.CreateLobbyPayload)
+    if !ok {
+        t.Fatalf("payload is %T, want *shared.CreateLobbyPayload", got.Payload)
+    }
+    if *payload != want {
+        t.Fatalf("got %+v, want %+v", *payload, want)
+    }
+}
+
+// TestBinaryCodecRoundTripsMessageWithNoPayload checks messages like
+// MsgRequestLobbies, which have no registered payload factory, decode back
+// to a nil Payload instead of erroring.
+func TestBinaryCodecRoundTripsMessageWithNoPayload(t *testing.T) {
+    enc := NewBinaryCodec()
+    dec := NewBinaryCodec()
+
+    data, err := enc.Encode(shared.Envelope{Type: shared.MsgRequestLobbies})
+    if err != nil {
+        t.Fatalf("Encode: %v", err)
+    }
+
+    got, err := dec.Decode(data)
+    if err != nil {
+        t.Fatalf("Decode: %v", err)
+    }
+    if got.Type != shared.MsgRequestLobbies || got.Payload != nil {
+        t.Fatalf("got %+v, want {Type: MsgRequestLobbies, Payload: nil}", got)
+    }
+}
+
+// TestBinaryCodecEncoderReusesTypeDescriptor confirms a BinaryCodec's
+// encoder only sends a payload type's gob descriptor once: the second
+// frame for the same type should be smaller than the first.
+func TestBinaryCodecEncoderReusesTypeDescriptor(t *testing.T) {
+    enc := NewBinaryCodec()
+
+    env := shared.Envelope{
+        Type: shared.MsgWorldSnapshot,
+        Payload: shared.WorldSnapshotPayload{
+            Tick:  1,
+            Tanks: []shared.TankSnapshot{{ID: "a", X: 1, Y: 2, HP: 100}},
+        },
+    }
+
+    first, err := enc.Encode(env)
+    if err != nil {
+        t.Fatalf("Encode: %v", err)
+    }
+    second, err := enc.Encode(env)
+    if err != nil {
+        t.Fatalf("Encode: %v", err)
+    }
+
+    if len(second) >= len(first) {
+        t.Fatalf("second frame (%d bytes) should be smaller than the first (%d bytes) once the type descriptor was already sent", len(second), len(first))
+    }
+}
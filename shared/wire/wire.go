@@ -0,0 +1,44 @@
+// Package wire implements the compact binary envelope used for
+// high-frequency gameplay messages, as an alternative to the JSON Envelope
+// in the shared package. A frame is [uint16 type][uint32 length][payload].
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const headerSize = 2 + 4
+
+// Frame is one binary envelope: a stable message type id plus its
+// gob-encoded payload bytes.
+type Frame struct {
+    Type    uint16
+    Payload []byte
+}
+
+// Encode lays out a Frame as [uint16 type][uint32 length][payload].
+func Encode(f Frame) []byte {
+    buf := make([]byte, headerSize+len(f.Payload))
+    binary.BigEndian.PutUint16(buf[0:2], f.Type)
+    binary.BigEndian.PutUint32(buf[2:6], uint32(len(f.Payload)))
+    copy(buf[headerSize:], f.Payload)
+    return buf
+}
+
+// Decode parses a Frame laid out by Encode.
+func Decode(data []byte) (Frame, error) {
+    if len(data) < headerSize {
+        return Frame{}, errors.New("wire: frame shorter than header")
+    }
+
+    length := binary.BigEndian.Uint32(data[2:6])
+    if int(length) != len(data)-headerSize {
+        return Frame{}, errors.New("wire: length prefix doesn't match payload")
+    }
+
+    return Frame{
+        Type:    binary.BigEndian.Uint16(data[0:2]),
+        Payload: data[headerSize:],
+    }, nil
+}